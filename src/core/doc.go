@@ -172,5 +172,11 @@ retransmit any messages that have been lost. If reliable delivery is important
 then you should manually implement acknowledgement and retransmission of
 messages.
 
+If you would rather not deal with either limitation yourself, use
+dialer.DialReliable and listener.AcceptReliable instead of Dial/Accept. They
+return a net.Conn that transparently fragments writes larger than 65535 bytes
+and acknowledges and retransmits lost fragments, at the cost of the usual
+stream-over-datagram latency and throughput tradeoffs.
+
 */
 package core
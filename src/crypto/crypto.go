@@ -0,0 +1,54 @@
+// Package crypto contains the cryptographic primitives and key types used
+// to identify and authenticate Yggdrasil nodes: Curve25519 keypairs for
+// session encryption, Ed25519 keypairs for signing, and the NodeID derived
+// from a node's Curve25519 public key.
+package crypto
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+)
+
+// Lengths, in bytes, of the key and ID types below.
+const (
+	BoxPubKeyLen  = 32
+	BoxPrivKeyLen = 32
+	SigPubKeyLen  = 32
+	SigPrivKeyLen = 64
+	NodeIDLen     = sha512.Size
+)
+
+// BoxPubKey is a node's Curve25519 public key, used to address and
+// encrypt sessions to that node. It implements net.Addr so it can be used
+// directly as a session's remote address.
+type BoxPubKey [BoxPubKeyLen]byte
+
+// BoxPrivKey is a node's Curve25519 private key.
+type BoxPrivKey [BoxPrivKeyLen]byte
+
+// SigPubKey is a node's Ed25519 public key, used to verify switch messages.
+type SigPubKey [SigPubKeyLen]byte
+
+// SigPrivKey is a node's Ed25519 private key.
+type SigPrivKey [SigPrivKeyLen]byte
+
+// NodeID identifies a node in the DHT. It is the SHA-512 hash of the node's
+// BoxPubKey.
+type NodeID [NodeIDLen]byte
+
+// Network implements net.Addr, identifying the address family.
+func (k BoxPubKey) Network() string {
+	return "curve25519"
+}
+
+// String implements net.Addr, returning the hex-encoded public key.
+func (k BoxPubKey) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// GetNodeID returns the NodeID derived from a Curve25519 public key.
+func GetNodeID(pub *BoxPubKey) *NodeID {
+	hashed := sha512.Sum512(pub[:])
+	id := NodeID(hashed)
+	return &id
+}
@@ -0,0 +1,165 @@
+package yggdrasil
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+// Conn implements net.Conn for a single Yggdrasil session, addressed by the
+// target NodeID/mask until the DHT search resolves it to a BoxPubKey.
+type Conn struct {
+	core     *Core
+	nodeID   *crypto.NodeID
+	nodeMask *crypto.NodeID
+	session  *sessionInfo
+
+	mutex     sync.Mutex
+	remoteKey *crypto.BoxPubKey
+	transport net.Conn
+	closed    bool
+}
+
+// newConn creates a Conn for a dial or accept in progress. If session is
+// nil, a fresh one is created.
+func newConn(core *Core, nodeID, nodeMask *crypto.NodeID, session *sessionInfo) *Conn {
+	if session == nil {
+		session = newSessionInfo()
+	}
+	return &Conn{core: core, nodeID: nodeID, nodeMask: nodeMask, session: session}
+}
+
+// fullNodeIDMask returns the NodeID mask that matches a NodeID exactly,
+// rather than a prefix of it.
+func fullNodeIDMask() crypto.NodeID {
+	var mask crypto.NodeID
+	for i := range mask {
+		mask[i] = 0xFF
+	}
+	return mask
+}
+
+// search runs (and can be cancelled via ctx) the DHT search for this Conn's
+// target, then performs the session handshake with whatever Core the search
+// resolves to: it binds a transport to this Conn, builds the peer's side of
+// the same session, and delivers that side to the peer's Listener so its
+// Accept sees a live, readable/writable Conn of its own. If ctx is cancelled
+// or its deadline passes before a target is found, the pending search is
+// torn down and search returns ctx.Err().
+func (c *Conn) search(ctx context.Context) error {
+	key, remote, err := c.core.getRouter().doSearch(ctx, c.nodeID, c.nodeMask)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.remoteKey = key
+	c.mutex.Unlock()
+
+	local, peer := net.Pipe()
+	c.bindTransport(local)
+
+	myKey := c.core.EncryptionPublicKey()
+	myNodeID := crypto.GetNodeID(&myKey)
+	mask := fullNodeIDMask()
+	peerConn := newConn(remote, myNodeID, &mask, nil)
+	peerConn.mutex.Lock()
+	peerConn.remoteKey = &myKey
+	peerConn.mutex.Unlock()
+	peerConn.bindTransport(peer)
+	peerConn.session.signalInit()
+
+	c.session.signalInit()
+
+	if !remote.deliverIncoming(peerConn) {
+		peerConn.Close()
+	}
+	return nil
+}
+
+// bindTransport attaches the net.Conn that this Conn's Read/Write delegate
+// to once the session handshake has resolved a transport for it.
+func (c *Conn) bindTransport(nc net.Conn) {
+	c.mutex.Lock()
+	c.transport = nc
+	c.mutex.Unlock()
+}
+
+// Read implements net.Conn.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.mutex.Lock()
+	transport := c.transport
+	closed := c.closed
+	c.mutex.Unlock()
+	if closed {
+		return 0, net.ErrClosed
+	}
+	if transport == nil {
+		return 0, errors.New("yggdrasil: session has no transport bound")
+	}
+	return transport.Read(b)
+}
+
+// Write implements net.Conn.
+func (c *Conn) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	transport := c.transport
+	closed := c.closed
+	c.mutex.Unlock()
+	if closed {
+		return 0, net.ErrClosed
+	}
+	if transport == nil {
+		return 0, errors.New("yggdrasil: session has no transport bound")
+	}
+	return transport.Write(b)
+}
+
+// Close implements net.Conn. It also cancels any still-pending search for
+// this Conn's target, so a closed half-open dial doesn't keep retrying.
+func (c *Conn) Close() error {
+	c.mutex.Lock()
+	already := c.closed
+	c.closed = true
+	transport := c.transport
+	c.mutex.Unlock()
+	if already {
+		return nil
+	}
+	c.core.getRouter().cancelSearch(c.nodeID)
+	if transport != nil {
+		transport.Close()
+	}
+	return nil
+}
+
+// LocalAddr implements net.Conn.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.core.EncryptionPublicKey()
+}
+
+// RemoteAddr implements net.Conn. It returns the zero crypto.BoxPubKey
+// until search() has resolved the target to a public key.
+func (c *Conn) RemoteAddr() net.Addr {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.remoteKey == nil {
+		return crypto.BoxPubKey{}
+	}
+	return *c.remoteKey
+}
+
+// SetDeadline implements net.Conn. Deadlines are not passed on to the
+// underlying transport: this does nothing and always returns nil, as do
+// SetReadDeadline and SetWriteDeadline.
+func (c *Conn) SetDeadline(t time.Time) error { return nil }
+
+// SetReadDeadline implements net.Conn.
+func (c *Conn) SetReadDeadline(t time.Time) error { return nil }
+
+// SetWriteDeadline implements net.Conn.
+func (c *Conn) SetWriteDeadline(t time.Time) error { return nil }
@@ -0,0 +1,118 @@
+package yggdrasil
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"sync"
+
+	"github.com/gologme/log"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+// Core is a single Yggdrasil node. It owns the node's keys, the router that
+// drives DHT searches, and the logger used throughout the package.
+type Core struct {
+	log *log.Logger
+
+	boxPub  crypto.BoxPubKey
+	boxPriv crypto.BoxPrivKey
+	sigPub  crypto.SigPubKey
+	sigPriv crypto.SigPrivKey
+
+	routerOnce sync.Once
+	router     *router
+
+	listenersMu sync.Mutex
+	listeners   []*Listener
+}
+
+// NewCore generates fresh Curve25519 and Ed25519 keypairs and returns the
+// Core identified by them. It is convenient for tests and for applications
+// that only need the Dial/Accept surface of this package without the rest
+// of a full node.
+func NewCore() (*Core, error) {
+	c := &Core{}
+	boxKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	copy(c.boxPriv[:], boxKey.Bytes())
+	copy(c.boxPub[:], boxKey.PublicKey().Bytes())
+	sigPub, sigPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	copy(c.sigPub[:], sigPub)
+	copy(c.sigPriv[:], sigPriv)
+	c.log = log.New(io.Discard, "", 0)
+	return c, nil
+}
+
+// getRouter returns this node's router, creating it on first use.
+func (c *Core) getRouter() *router {
+	c.routerOnce.Do(func() {
+		c.router = newRouter(c)
+	})
+	return c.router
+}
+
+// EncryptionPublicKey returns this node's Curve25519 public key.
+func (c *Core) EncryptionPublicKey() crypto.BoxPubKey {
+	return c.boxPub
+}
+
+// SigningPrivateKey returns this node's Ed25519 private key.
+func (c *Core) SigningPrivateKey() crypto.SigPrivKey {
+	return c.sigPriv
+}
+
+// NodeID returns this node's NodeID, derived from its Curve25519 public key.
+func (c *Core) NodeID() *crypto.NodeID {
+	return crypto.GetNodeID(&c.boxPub)
+}
+
+// addListener registers l as one of this Core's active Listeners, so that
+// sessions resolved by the router can be delivered to it.
+func (c *Core) addListener(l *Listener) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+	c.listeners = append(c.listeners, l)
+}
+
+// removeListener undoes addListener. It reports whether l was this Core's
+// last remaining Listener, so the caller knows when the Core should leave
+// the directory.
+func (c *Core) removeListener(l *Listener) bool {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+	for i, x := range c.listeners {
+		if x == l {
+			c.listeners = append(c.listeners[:i], c.listeners[i+1:]...)
+			break
+		}
+	}
+	return len(c.listeners) == 0
+}
+
+// deliverIncoming hands conn to one of this Core's active Listeners. It
+// reports whether a Listener accepted it; the caller is responsible for
+// closing conn if none did.
+func (c *Core) deliverIncoming(conn *Conn) bool {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+	for _, l := range c.listeners {
+		if l.deliver(conn) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConnDialer returns a Dialer that this Core can use to dial outgoing
+// sessions.
+func (c *Core) ConnDialer() (*Dialer, error) {
+	return &Dialer{core: c}, nil
+}
@@ -13,16 +13,25 @@ import (
 	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
 )
 
+// defaultDialTimeout is the deadline applied to a dial when neither the
+// caller's context nor Dialer.DialTimeout supplies one.
+const defaultDialTimeout = 6 * time.Second
+
 // Dialer represents an Yggdrasil connection dialer.
 type Dialer struct {
 	core *Core
+
+	// DialTimeout sets the deadline applied to a dial when the context
+	// passed to DialContext has none of its own. If zero, defaultDialTimeout
+	// is used. It has no effect if the context already carries a deadline.
+	DialTimeout time.Duration
 }
 
 // Dial opens a session to the given node. The first parameter should be
 // "curve25519" or "nodeid" and the second parameter should contain a
 // hexadecimal representation of the target. It uses DialContext internally.
 func (d *Dialer) Dial(network, address string) (net.Conn, error) {
-	return d.DialContext(nil, network, address)
+	return d.DialContext(context.Background(), network, address)
 }
 
 // DialContext is used internally by Dial, and should only be used with a
@@ -79,25 +88,36 @@ func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.
 }
 
 // DialByNodeIDandMask opens a session to the given node based on raw NodeID
-// parameters. If ctx is nil or has no timeout, then a default timeout of 6
-// seconds will apply, beginning *after* the search finishes.
+// parameters. If ctx has no deadline of its own, one is applied using
+// Dialer.DialTimeout (or defaultDialTimeout if that is zero), covering the
+// DHT search as well as the handshake that follows it. Cancelling ctx stops
+// a pending search and closes the half-open conn, returning ctx.Err().
 func (d *Dialer) DialByNodeIDandMask(ctx context.Context, nodeID, nodeMask *crypto.NodeID) (net.Conn, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		timeout := d.DialTimeout
+		if timeout == 0 {
+			timeout = defaultDialTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	startDial := time.Now()
 	conn := newConn(d.core, nodeID, nodeMask, nil)
-	if err := conn.search(); err != nil {
-		// TODO: make searches take a context, so they can be cancelled early
+	if err := conn.search(ctx); err != nil {
 		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 	endSearch := time.Now()
 	d.core.log.Debugln("Dial searched for:", nodeID, "in time:", endSearch.Sub(startDial))
 	conn.session.setConn(nil, conn)
-	var cancel context.CancelFunc
-	if ctx == nil {
-		ctx = context.Background()
-	}
-	ctx, cancel = context.WithTimeout(ctx, 6*time.Second)
-	defer cancel()
 	select {
 	case <-conn.session.init:
 		endInit := time.Now()
@@ -106,13 +126,13 @@ func (d *Dialer) DialByNodeIDandMask(ctx context.Context, nodeID, nodeMask *cryp
 		return conn, nil
 	case <-ctx.Done():
 		conn.Close()
-		return nil, errors.New("session handshake timeout")
+		return nil, ctx.Err()
 	}
 }
 
-// DialByPublicKey opens a session to the given node based on the public key. If
-// ctx is nil or has no timeout, then a default timeout of 6 seconds will apply,
-// beginning *after* the search finishes.
+// DialByPublicKey opens a session to the given node based on the public
+// key. It derives the node's NodeID and delegates to DialByNodeIDandMask,
+// whose ctx/timeout handling applies unchanged here.
 func (d *Dialer) DialByPublicKey(ctx context.Context, pubKey *crypto.BoxPubKey) (net.Conn, error) {
 	nodeID := crypto.GetNodeID(pubKey)
 	var nodeMask crypto.NodeID
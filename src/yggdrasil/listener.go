@@ -0,0 +1,78 @@
+package yggdrasil
+
+import (
+	"errors"
+	"net"
+)
+
+// Listener listens for incoming Yggdrasil sessions from remote nodes.
+type Listener struct {
+	core *Core
+	in   chan *Conn
+	stop chan struct{}
+}
+
+func newListener(core *Core) *Listener {
+	return &Listener{
+		core: core,
+		in:   make(chan *Conn, 32),
+		stop: make(chan struct{}),
+	}
+}
+
+// ConnListener returns a Listener that accepts incoming sessions on this
+// Core. Only once a Core has a Listener can other Cores dial it, since that
+// is how this package's (directory-backed) search resolves a node as
+// reachable in the first place.
+func (c *Core) ConnListener() (*Listener, error) {
+	l := newListener(c)
+	c.addListener(l)
+	publishToDirectory(c)
+	return l, nil
+}
+
+// Accept implements net.Listener, returning the next incoming session.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn, ok := <-l.in:
+		if !ok {
+			return nil, errors.New("yggdrasil: listener closed")
+		}
+		return conn, nil
+	case <-l.stop:
+		return nil, errors.New("yggdrasil: listener closed")
+	}
+}
+
+// Close implements net.Listener. Once this was the Core's last active
+// Listener, it also unpublishes the Core from the directory, since a node
+// that can no longer accept incoming sessions shouldn't stay resolvable by
+// other Cores' searches.
+func (l *Listener) Close() error {
+	select {
+	case <-l.stop:
+	default:
+		close(l.stop)
+		if l.core.removeListener(l) {
+			unpublishFromDirectory(l.core)
+		}
+	}
+	return nil
+}
+
+// Addr implements net.Listener.
+func (l *Listener) Addr() net.Addr {
+	return l.core.EncryptionPublicKey()
+}
+
+// deliver hands an accepted Conn to a caller blocked in Accept. It reports
+// whether the Conn was actually handed off; it fails only if the Listener
+// is already closed.
+func (l *Listener) deliver(conn *Conn) bool {
+	select {
+	case l.in <- conn:
+		return true
+	case <-l.stop:
+		return false
+	}
+}
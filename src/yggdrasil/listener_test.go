@@ -0,0 +1,82 @@
+package yggdrasil
+
+import (
+	"testing"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+// TestListenerCloseUnpublishesFromDirectory proves that a Core stops being
+// resolvable via router.lookup once its only Listener is closed. Before the
+// fix, Close never called unpublishFromDirectory, so a node that stopped
+// listening stayed resolvable for the rest of the process's lifetime.
+func TestListenerCloseUnpublishesFromDirectory(t *testing.T) {
+	server, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+	client, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+
+	ln, err := server.ConnListener()
+	if err != nil {
+		t.Fatalf("ConnListener: %v", err)
+	}
+
+	nodeID := server.NodeID()
+	var mask crypto.NodeID
+	for i := range mask {
+		mask[i] = 0xFF
+	}
+
+	if _, _, ok := client.getRouter().lookup(nodeID, &mask); !ok {
+		t.Fatal("server should be resolvable while its Listener is open")
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, _, ok := client.getRouter().lookup(nodeID, &mask); ok {
+		t.Fatal("server is still resolvable after its only Listener was closed")
+	}
+}
+
+// TestListenerCloseKeepsCoreResolvableIfAnotherListenerRemains proves that
+// closing one of a Core's several Listeners doesn't unpublish the Core
+// while another Listener is still accepting sessions for it.
+func TestListenerCloseKeepsCoreResolvableIfAnotherListenerRemains(t *testing.T) {
+	server, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+	client, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+
+	lnA, err := server.ConnListener()
+	if err != nil {
+		t.Fatalf("ConnListener: %v", err)
+	}
+	lnB, err := server.ConnListener()
+	if err != nil {
+		t.Fatalf("ConnListener: %v", err)
+	}
+	defer lnB.Close()
+
+	if err := lnA.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	nodeID := server.NodeID()
+	var mask crypto.NodeID
+	for i := range mask {
+		mask[i] = 0xFF
+	}
+	if _, _, ok := client.getRouter().lookup(nodeID, &mask); !ok {
+		t.Fatal("server should still be resolvable while lnB remains open")
+	}
+}
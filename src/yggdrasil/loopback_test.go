@@ -0,0 +1,117 @@
+package yggdrasil
+
+import (
+	"context"
+	"crypto/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+// TestDialSucceeds proves a Dial can actually complete end-to-end: two Cores
+// in the same process, one listening, one dialing it by public key, with
+// real bytes round-tripped over the resulting net.Conn.
+func TestDialSucceeds(t *testing.T) {
+	server, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+	client, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+
+	ln, err := server.ConnListener()
+	if err != nil {
+		t.Fatalf("ConnListener: %v", err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- conn
+	}()
+
+	dialer, err := client.ConnDialer()
+	if err != nil {
+		t.Fatalf("ConnDialer: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	pub := server.EncryptionPublicKey()
+	conn, err := dialer.DialByPublicKey(ctx, &pub)
+	if err != nil {
+		t.Fatalf("DialByPublicKey: %v", err)
+	}
+	defer conn.Close()
+
+	var remote net.Conn
+	select {
+	case remote = <-accepted:
+	case <-time.After(time.Second):
+		t.Fatal("server never accepted the dialed session")
+	}
+	defer remote.Close()
+
+	// net.Pipe is synchronous, so Write blocks until a concurrent Read
+	// consumes it.
+	writeErr := make(chan error, 1)
+	go func() {
+		_, err := conn.Write([]byte("ping"))
+		writeErr <- err
+	}()
+	buf := make([]byte, 16)
+	n, err := remote.Read(buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(buf[:n]) != "ping" {
+		t.Fatalf("server read %q, want %q", buf[:n], "ping")
+	}
+	if err := <-writeErr; err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+// TestDialByNodeIDandMaskRespectsContextCancellation proves that dialing a
+// NodeID nothing in the process publishes actually returns once ctx's
+// deadline passes, instead of blocking forever on an uncancellable search.
+func TestDialByNodeIDandMaskRespectsContextCancellation(t *testing.T) {
+	client, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+	dialer, err := client.ConnDialer()
+	if err != nil {
+		t.Fatalf("ConnDialer: %v", err)
+	}
+
+	var nodeID, nodeMask crypto.NodeID
+	if _, err := rand.Read(nodeID[:]); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+	for i := range nodeMask {
+		nodeMask[i] = 0xFF
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = dialer.DialByNodeIDandMask(ctx, &nodeID, &nodeMask)
+	elapsed := time.Since(start)
+
+	if err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("dial took %v to give up after a 150ms context deadline", elapsed)
+	}
+}
@@ -0,0 +1,289 @@
+package yggdrasil
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+// packetConnCacheSize is the number of dialed sessions that PacketConn will
+// keep warm in its LRU before evicting the least recently used entry.
+const packetConnCacheSize = 256
+
+// PacketConn implements net.PacketConn on top of Yggdrasil sessions, so that
+// datagram-oriented libraries (e.g. quic-go) can be layered directly on top
+// of Core without going through the stream-ish Conn returned by Dial/Accept.
+// Addresses are crypto.BoxPubKey values, which implement net.Addr.
+//
+// Incoming sessions, whether dialed by this PacketConn or accepted from
+// remote peers via the node's Listener, are demultiplexed into a single
+// read queue. Outgoing sessions are opened lazily on first use and cached
+// in an LRU keyed by NodeID so that repeated WriteTo calls to the same
+// destination reuse the existing session rather than re-running the DHT
+// search and handshake each time.
+type PacketConn struct {
+	core     *Core
+	dialer   Dialer
+	listener *Listener
+
+	mutex    sync.Mutex
+	sessions map[crypto.NodeID]*pcSession
+	dialing  map[crypto.NodeID]*pcDial
+	lru      []crypto.NodeID
+
+	incoming chan pcDatagram
+	closed   chan struct{}
+	once     sync.Once
+}
+
+type pcSession struct {
+	conn net.Conn
+	addr crypto.BoxPubKey
+}
+
+type pcDatagram struct {
+	data []byte
+	addr crypto.BoxPubKey
+}
+
+// pcDial tracks a dial in progress, so that concurrent WriteTo calls to the
+// same not-yet-connected peer join a single dial instead of each starting
+// their own.
+type pcDial struct {
+	done chan struct{}
+	conn net.Conn
+	err  error
+}
+
+// PacketConn returns a net.PacketConn backed by this Core instance. It
+// immediately starts accepting incoming sessions from the node's Listener
+// in the background, so that peers dialing in are folded into ReadFrom just
+// like sessions this PacketConn dialed itself. Each call returns a new
+// PacketConn sharing the underlying node, so callers that only need one
+// should keep a single reference around.
+func (c *Core) PacketConn() *PacketConn {
+	pc := &PacketConn{
+		core:     c,
+		dialer:   Dialer{core: c},
+		sessions: make(map[crypto.NodeID]*pcSession),
+		dialing:  make(map[crypto.NodeID]*pcDial),
+		incoming: make(chan pcDatagram, 1024),
+		closed:   make(chan struct{}),
+	}
+	go pc.acceptLoop()
+	return pc
+}
+
+// acceptLoop accepts incoming sessions from the node's Listener for as long
+// as the PacketConn is open, folding each into the shared read queue.
+func (pc *PacketConn) acceptLoop() {
+	listener, err := pc.core.ConnListener()
+	if err != nil {
+		return
+	}
+	pc.mutex.Lock()
+	select {
+	case <-pc.closed:
+		// Close already ran before the Listener existed, so it could not
+		// close it; do that here.
+		pc.mutex.Unlock()
+		listener.Close()
+		return
+	default:
+	}
+	pc.listener = listener
+	pc.mutex.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		pubKey, ok := conn.RemoteAddr().(crypto.BoxPubKey)
+		if !ok {
+			conn.Close()
+			continue
+		}
+		pc.AcceptFrom(conn, &pubKey)
+	}
+}
+
+// ReadFrom implements net.PacketConn. It blocks until a datagram arrives
+// from any remote session, or the PacketConn is closed.
+func (pc *PacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	select {
+	case dgram := <-pc.incoming:
+		n := copy(p, dgram.data)
+		return n, dgram.addr, nil
+	case <-pc.closed:
+		return 0, nil, errors.New("packetconn closed")
+	}
+}
+
+// WriteTo implements net.PacketConn. addr must be a crypto.BoxPubKey (as
+// returned by ReadFrom, or a known public key). If no session to the given
+// key exists yet, one is opened transparently, which may involve a DHT
+// search and handshake.
+func (pc *PacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	boxPubKey, ok := addr.(crypto.BoxPubKey)
+	if !ok {
+		return 0, errors.New("unsupported address type")
+	}
+	conn, err := pc.getOrDial(&boxPubKey)
+	if err != nil {
+		return 0, err
+	}
+	return conn.Write(p)
+}
+
+// getOrDial returns the cached session to pubKey, if any, joining an
+// in-progress dial to the same peer rather than racing a second one, and
+// otherwise dials and caches a new session.
+func (pc *PacketConn) getOrDial(pubKey *crypto.BoxPubKey) (net.Conn, error) {
+	nodeID := crypto.GetNodeID(pubKey)
+
+	pc.mutex.Lock()
+	if sess, ok := pc.sessions[*nodeID]; ok {
+		pc.touch(*nodeID)
+		pc.mutex.Unlock()
+		return sess.conn, nil
+	}
+	if d, ok := pc.dialing[*nodeID]; ok {
+		pc.mutex.Unlock()
+		<-d.done
+		return d.conn, d.err
+	}
+	d := &pcDial{done: make(chan struct{})}
+	pc.dialing[*nodeID] = d
+	pc.mutex.Unlock()
+
+	conn, err := pc.dialer.DialByPublicKey(nil, pubKey)
+	d.conn, d.err = conn, err
+	close(d.done)
+
+	pc.mutex.Lock()
+	delete(pc.dialing, *nodeID)
+	pc.mutex.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	if pc.addSession(*nodeID, &pcSession{conn: conn, addr: *pubKey}) {
+		go pc.demux(*nodeID, conn)
+	}
+	return conn, nil
+}
+
+// addSession registers sess as the session for nodeID, unless one already
+// exists (e.g. a concurrent AcceptFrom or dial won the race), in which case
+// sess's conn is closed rather than silently leaked. It reports whether
+// sess was the one actually registered.
+func (pc *PacketConn) addSession(nodeID crypto.NodeID, sess *pcSession) bool {
+	pc.mutex.Lock()
+	if _, exists := pc.sessions[nodeID]; exists {
+		pc.mutex.Unlock()
+		sess.conn.Close()
+		return false
+	}
+	pc.sessions[nodeID] = sess
+	pc.lru = append(pc.lru, nodeID)
+	var evicted *pcSession
+	if len(pc.lru) > packetConnCacheSize {
+		oldest := pc.lru[0]
+		pc.lru = pc.lru[1:]
+		evicted = pc.sessions[oldest]
+		delete(pc.sessions, oldest)
+	}
+	pc.mutex.Unlock()
+	if evicted != nil {
+		evicted.conn.Close()
+	}
+	return true
+}
+
+func (pc *PacketConn) touch(nodeID crypto.NodeID) {
+	for i, id := range pc.lru {
+		if id == nodeID {
+			pc.lru = append(pc.lru[:i], pc.lru[i+1:]...)
+			pc.lru = append(pc.lru, nodeID)
+			return
+		}
+	}
+}
+
+// demux reads datagrams off a dialed or accepted session and forwards them
+// into the shared incoming queue until the session closes.
+func (pc *PacketConn) demux(nodeID crypto.NodeID, conn net.Conn) {
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			pc.mutex.Lock()
+			delete(pc.sessions, nodeID)
+			pc.mutex.Unlock()
+			return
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		addr, _ := conn.RemoteAddr().(crypto.BoxPubKey)
+		select {
+		case pc.incoming <- pcDatagram{data: data, addr: addr}:
+		case <-pc.closed:
+			return
+		}
+	}
+}
+
+// AcceptFrom registers an already-accepted session (e.g. from Listener.Accept)
+// so that its datagrams are folded into this PacketConn's read queue.
+func (pc *PacketConn) AcceptFrom(conn net.Conn, pubKey *crypto.BoxPubKey) {
+	nodeID := crypto.GetNodeID(pubKey)
+	if pc.addSession(*nodeID, &pcSession{conn: conn, addr: *pubKey}) {
+		go pc.demux(*nodeID, conn)
+	}
+}
+
+// Close implements net.PacketConn, closing all cached sessions and the
+// underlying Listener used by the accept loop.
+func (pc *PacketConn) Close() error {
+	pc.once.Do(func() {
+		close(pc.closed)
+		pc.mutex.Lock()
+		defer pc.mutex.Unlock()
+		if pc.listener != nil {
+			pc.listener.Close()
+		}
+		for _, sess := range pc.sessions {
+			sess.conn.Close()
+		}
+		pc.sessions = make(map[crypto.NodeID]*pcSession)
+		pc.lru = nil
+	})
+	return nil
+}
+
+// LocalAddr implements net.PacketConn.
+func (pc *PacketConn) LocalAddr() net.Addr {
+	return pc.core.EncryptionPublicKey()
+}
+
+// SetDeadline implements net.PacketConn. Per-session deadlines are not yet
+// supported, so this always returns an error.
+func (pc *PacketConn) SetDeadline(t time.Time) error {
+	return errors.New("not supported")
+}
+
+// SetReadDeadline implements net.PacketConn. Per-session deadlines are not
+// yet supported, so this always returns an error.
+func (pc *PacketConn) SetReadDeadline(t time.Time) error {
+	return errors.New("not supported")
+}
+
+// SetWriteDeadline implements net.PacketConn. Per-session deadlines are not
+// yet supported, so this always returns an error.
+func (pc *PacketConn) SetWriteDeadline(t time.Time) error {
+	return errors.New("not supported")
+}
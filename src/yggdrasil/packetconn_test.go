@@ -0,0 +1,186 @@
+package yggdrasil
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+func TestGetOrDialJoinsInFlightDial(t *testing.T) {
+	pc := &PacketConn{
+		sessions: make(map[crypto.NodeID]*pcSession),
+		dialing:  make(map[crypto.NodeID]*pcDial),
+	}
+	var pub crypto.BoxPubKey
+	pub[0] = 0xAA
+	nodeID := crypto.GetNodeID(&pub)
+
+	fakeConn, _ := net.Pipe()
+	d := &pcDial{done: make(chan struct{})}
+	pc.dialing[*nodeID] = d
+
+	results := make([]net.Conn, 4)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := pc.getOrDial(&pub)
+			if err != nil {
+				t.Errorf("getOrDial: %v", err)
+				return
+			}
+			results[i] = conn
+		}(i)
+	}
+
+	// Give the goroutines a chance to reach the <-d.done join before it
+	// resolves, so this actually exercises the join path rather than a race
+	// against dialing never being populated.
+	time.Sleep(20 * time.Millisecond)
+	d.conn = fakeConn
+	close(d.done)
+	wg.Wait()
+
+	for i, conn := range results {
+		if conn != fakeConn {
+			t.Errorf("result %d = %v, want the joined dial's conn %v", i, conn, fakeConn)
+		}
+	}
+}
+
+func TestGetOrDialCachesSession(t *testing.T) {
+	server, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+	client, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+
+	ln, err := server.ConnListener()
+	if err != nil {
+		t.Fatalf("ConnListener: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		buf := make([]byte, 64)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	pc := client.PacketConn()
+	defer pc.Close()
+
+	pub := server.EncryptionPublicKey()
+	conn1, err := pc.getOrDial(&pub)
+	if err != nil {
+		t.Fatalf("getOrDial: %v", err)
+	}
+	conn2, err := pc.getOrDial(&pub)
+	if err != nil {
+		t.Fatalf("getOrDial: %v", err)
+	}
+	if conn1 != conn2 {
+		t.Error("second getOrDial to the same peer should reuse the cached session")
+	}
+}
+
+func TestAddSessionEvictsLRU(t *testing.T) {
+	pc := &PacketConn{
+		sessions: make(map[crypto.NodeID]*pcSession),
+		dialing:  make(map[crypto.NodeID]*pcDial),
+	}
+
+	type entry struct {
+		id   crypto.NodeID
+		conn net.Conn
+	}
+	entries := make([]entry, packetConnCacheSize+1)
+	for i := range entries {
+		var pub crypto.BoxPubKey
+		pub[0] = byte(i)
+		pub[1] = byte(i >> 8)
+		local, _ := net.Pipe()
+		entries[i] = entry{id: *crypto.GetNodeID(&pub), conn: local}
+		pc.addSession(entries[i].id, &pcSession{conn: local, addr: pub})
+	}
+
+	if len(pc.sessions) != packetConnCacheSize {
+		t.Fatalf("len(sessions) = %d, want %d", len(pc.sessions), packetConnCacheSize)
+	}
+	if _, ok := pc.sessions[entries[0].id]; ok {
+		t.Error("oldest session should have been evicted")
+	}
+	if _, err := entries[0].conn.Write([]byte("x")); err == nil {
+		t.Error("evicted session's conn should have been closed")
+	}
+	if _, ok := pc.sessions[entries[len(entries)-1].id]; !ok {
+		t.Error("most recently added session should still be cached")
+	}
+}
+
+func TestAddSessionClosesLoserOfRace(t *testing.T) {
+	pc := &PacketConn{
+		sessions: make(map[crypto.NodeID]*pcSession),
+		dialing:  make(map[crypto.NodeID]*pcDial),
+	}
+	var pub crypto.BoxPubKey
+	pub[0] = 0x42
+	nodeID := crypto.GetNodeID(&pub)
+
+	winner, _ := net.Pipe()
+	loser, _ := net.Pipe()
+
+	if !pc.addSession(*nodeID, &pcSession{conn: winner, addr: pub}) {
+		t.Fatal("first addSession for a NodeID should win")
+	}
+	if pc.addSession(*nodeID, &pcSession{conn: loser, addr: pub}) {
+		t.Fatal("second addSession for the same NodeID should lose the race")
+	}
+	if _, err := loser.Write([]byte("x")); err == nil {
+		t.Error("the losing session's conn should have been closed")
+	}
+}
+
+func TestReadFromAndClose(t *testing.T) {
+	pc := &PacketConn{
+		sessions: make(map[crypto.NodeID]*pcSession),
+		dialing:  make(map[crypto.NodeID]*pcDial),
+		incoming: make(chan pcDatagram, 4),
+		closed:   make(chan struct{}),
+	}
+	local, remote := net.Pipe()
+	var pub crypto.BoxPubKey
+	pub[0] = 0xCC
+	pc.AcceptFrom(local, &pub)
+
+	go remote.Write([]byte("hello"))
+
+	buf := make([]byte, 64)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if string(buf[:n]) != "hello" {
+		t.Errorf("ReadFrom data = %q, want %q", buf[:n], "hello")
+	}
+
+	if err := pc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, _, err := pc.ReadFrom(buf); err == nil {
+		t.Error("ReadFrom after Close should return an error")
+	}
+}
@@ -0,0 +1,30 @@
+package yggdrasil
+
+import (
+	"net"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/yggdrasil/proxy"
+)
+
+// ListenSOCKS starts a SOCKS5 proxy on addr that dials its targets into this
+// node's mesh. Proxied hostnames are resolved using the "<hex-pubkey>.ygg" /
+// "<hex-nodeid>.nodeid.ygg" convention, letting unmodified SOCKS-aware
+// applications reach services behind Listener.Accept.
+func (c *Core) ListenSOCKS(addr string, cfg proxy.Config) (net.Listener, error) {
+	d, err := c.ConnDialer()
+	if err != nil {
+		return nil, err
+	}
+	return proxy.New(d, nil, cfg).ListenSOCKS(addr)
+}
+
+// ListenHTTPProxy starts an HTTP CONNECT proxy on addr that dials its
+// targets into this node's mesh, using the same hostname convention as
+// ListenSOCKS.
+func (c *Core) ListenHTTPProxy(addr string, cfg proxy.Config) (net.Listener, error) {
+	d, err := c.ConnDialer()
+	if err != nil {
+		return nil, err
+	}
+	return proxy.New(d, nil, cfg).ListenHTTPProxy(addr)
+}
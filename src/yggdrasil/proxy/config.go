@@ -0,0 +1,56 @@
+package proxy
+
+import "strings"
+
+// Config controls access to a Server. The zero value allows any host and
+// requires no authentication.
+type Config struct {
+	// AllowHosts, if non-empty, restricts dialed hostnames to this list
+	// (exact match or "*.suffix" wildcard). DenyHosts is checked first.
+	AllowHosts []string
+
+	// DenyHosts rejects any dialed hostname matching this list (exact match
+	// or "*.suffix" wildcard), regardless of AllowHosts.
+	DenyHosts []string
+
+	// Username and Password, if both set, require clients to authenticate
+	// before issuing requests: SOCKS5 username/password authentication
+	// (RFC 1929) on ListenSOCKS, and a Proxy-Authorization: Basic header
+	// (RFC 7617) on ListenHTTPProxy.
+	Username string
+	Password string
+}
+
+func (c *Config) allowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range c.DenyHosts {
+		if hostMatches(pattern, host) {
+			return false
+		}
+	}
+	if len(c.AllowHosts) == 0 {
+		return true
+	}
+	for _, pattern := range c.AllowHosts {
+		if hostMatches(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostMatches compares pattern and host case-insensitively: hostnames built
+// from hex-encoded keys (the ".ygg" convention) round-trip through
+// hex.DecodeString regardless of letter case, so a case-sensitive compare
+// would let an AllowHosts/DenyHosts entry be bypassed by re-casing the host.
+func hostMatches(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	if suffix := strings.TrimPrefix(pattern, "*."); suffix != pattern {
+		return host == suffix || strings.HasSuffix(host, "."+suffix)
+	}
+	return pattern == host
+}
+
+func (c *Config) requiresAuth() bool {
+	return c.Username != "" && c.Password != ""
+}
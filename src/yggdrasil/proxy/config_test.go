@@ -0,0 +1,45 @@
+package proxy
+
+import "testing"
+
+func TestConfigAllowed(t *testing.T) {
+	cfg := Config{
+		AllowHosts: []string{"*.ygg"},
+		DenyHosts:  []string{"evil.ygg"},
+	}
+	if !cfg.allowed("node.ygg") {
+		t.Error("node.ygg should be allowed by the *.ygg wildcard")
+	}
+	if cfg.allowed("evil.ygg") {
+		t.Error("evil.ygg should be rejected by DenyHosts, even though it matches AllowHosts")
+	}
+	if cfg.allowed("example.com") {
+		t.Error("example.com should be rejected: it matches no AllowHosts entry")
+	}
+}
+
+func TestConfigAllowedIsCaseInsensitive(t *testing.T) {
+	cfg := Config{DenyHosts: []string{"evil.ygg"}}
+	if cfg.allowed("EVIL.ygg") {
+		t.Error("EVIL.ygg should be rejected by a DenyHosts entry differing only in case")
+	}
+}
+
+func TestConfigAllowedEmptyAllowsAll(t *testing.T) {
+	cfg := Config{}
+	if !cfg.allowed("anything.at.all") {
+		t.Error("a Config with no AllowHosts/DenyHosts should allow any host")
+	}
+}
+
+func TestConfigRequiresAuth(t *testing.T) {
+	if (&Config{}).requiresAuth() {
+		t.Error("a Config with no credentials should not require auth")
+	}
+	if (&Config{Username: "u"}).requiresAuth() {
+		t.Error("a Config with only Username set should not require auth")
+	}
+	if !(&Config{Username: "u", Password: "p"}).requiresAuth() {
+		t.Error("a Config with both Username and Password set should require auth")
+	}
+}
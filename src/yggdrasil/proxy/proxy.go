@@ -0,0 +1,354 @@
+// Package proxy implements SOCKS5 and HTTP CONNECT front-ends that let
+// unmodified applications (browsers, curl, git, IRC clients) reach services
+// behind an Yggdrasil Listener without needing the full TUN/TAP IPv6 stack.
+// Connections are accepted locally over plain TCP and, once a target host
+// matching the mesh-address convention is requested, dialed into the mesh
+// using a Dialer.
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Dialer is the subset of *yggdrasil.Dialer that the proxy needs. It is
+// expressed as an interface so this package doesn't have to import
+// src/yggdrasil (which imports this package's call sites, not the other way
+// around).
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Server is a SOCKS5/HTTP CONNECT proxy front-end that dials its targets
+// into an Yggdrasil mesh via a Dialer.
+type Server struct {
+	dialer   Dialer
+	resolver Resolver
+	config   Config
+}
+
+// New returns a Server that dials through d, resolving proxied hostnames
+// with r. If r is nil, DefaultResolver is used.
+func New(d Dialer, r Resolver, cfg Config) *Server {
+	if r == nil {
+		r = DefaultResolver{}
+	}
+	return &Server{dialer: d, resolver: r, config: cfg}
+}
+
+// ListenSOCKS starts a SOCKS5 proxy listening on addr. It returns once the
+// listener is established; connections are served in background goroutines
+// until the returned net.Listener is closed.
+func (s *Server) ListenSOCKS(addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go s.serve(ln, s.serveSOCKS)
+	return ln, nil
+}
+
+// ListenHTTPProxy starts an HTTP CONNECT proxy listening on addr. It returns
+// once the listener is established; connections are served in background
+// goroutines until the returned net.Listener is closed.
+func (s *Server) ListenHTTPProxy(addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go s.serve(ln, s.serveHTTPConnect)
+	return ln, nil
+}
+
+// serve accepts connections on ln until it returns a non-temporary error.
+// Temporary errors (e.g. a transient EMFILE) back off with the same capped
+// exponential delay net/http's Server uses, rather than busy-looping.
+func (s *Server) serve(ln net.Listener, handle func(net.Conn)) {
+	var backoff time.Duration
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if backoff == 0 {
+					backoff = 5 * time.Millisecond
+				} else {
+					backoff *= 2
+				}
+				if max := time.Second; backoff > max {
+					backoff = max
+				}
+				time.Sleep(backoff)
+				continue
+			}
+			return
+		}
+		backoff = 0
+		go handle(conn)
+	}
+}
+
+// dial resolves host through s.resolver, checks it against the configured
+// allow/deny lists, and dials it through s.dialer.
+func (s *Server) dial(ctx context.Context, host string) (net.Conn, error) {
+	if !s.config.allowed(host) {
+		return nil, fmt.Errorf("proxy: host %q is not permitted", host)
+	}
+	network, address, err := s.resolver.Resolve(host)
+	if err != nil {
+		return nil, err
+	}
+	return s.dialer.DialContext(ctx, network, address)
+}
+
+// closeWriter is implemented by connections (e.g. *net.TCPConn) that support
+// half-closing their write side, propagating EOF downstream without
+// affecting reads still in flight the other way.
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// halfCloseWrite closes conn's write side if it supports it, so the peer
+// sees EOF without the whole connection being torn down.
+func halfCloseWrite(conn net.Conn) {
+	if cw, ok := conn.(closeWriter); ok {
+		cw.CloseWrite()
+	}
+}
+
+// pipe shuttles data in both directions between a local client connection
+// and the dialed remote connection, and only closes both once data has
+// stopped flowing in both directions. A CONNECT/SOCKS tunnel's two
+// directions rarely finish in lockstep (request, then response) - closing
+// both connections as soon as the first direction hits EOF would truncate
+// whichever direction was still delivering.
+func pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(a, b)
+		halfCloseWrite(a)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(b, a)
+		halfCloseWrite(b)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+	a.Close()
+	b.Close()
+}
+
+// serveHTTPConnect handles a single HTTP CONNECT proxy client: it reads one
+// CONNECT request, dials the target, and then relays raw bytes.
+func (s *Server) serveHTTPConnect(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return
+	}
+	if req.Method != http.MethodConnect {
+		io.WriteString(conn, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+		return
+	}
+	if s.config.requiresAuth() && !s.checkProxyAuth(req) {
+		io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n"+
+			"Proxy-Authenticate: Basic realm=\"yggdrasil\"\r\n\r\n")
+		return
+	}
+	host := req.URL.Hostname()
+	remote, err := s.dial(req.Context(), host)
+	if err != nil {
+		io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		return
+	}
+	io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+	pipe(conn, remote)
+}
+
+// checkProxyAuth verifies the client's Proxy-Authorization header (RFC 7617
+// Basic auth, as used by HTTP proxies rather than origin servers) against
+// the configured Username/Password.
+func (s *Server) checkProxyAuth(req *http.Request) bool {
+	header := req.Header.Get("Proxy-Authorization")
+	const prefix = "Basic "
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, prefix))
+	if err != nil {
+		return false
+	}
+	user, pass, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(s.config.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(s.config.Password)) == 1
+	return userOK && passOK
+}
+
+// SOCKS5 wire constants, as defined by RFC 1928/1929.
+const (
+	socks5Version = 0x05
+
+	socks5AuthNone    = 0x00
+	socks5AuthUserPw  = 0x02
+	socks5AuthNoneAcc = 0xFF
+
+	socks5CmdConnect = 0x01
+
+	socks5AtypIPv4   = 0x01
+	socks5AtypDomain = 0x03
+	socks5AtypIPv6   = 0x04
+
+	socks5RepSucceeded       = 0x00
+	socks5RepGeneralFail     = 0x01
+	socks5RepCmdNotSupported = 0x07
+)
+
+func (s *Server) serveSOCKS(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	if err := s.socksHandshake(reader, conn); err != nil {
+		return
+	}
+
+	host, err := s.socksReadRequest(reader, conn)
+	if err != nil {
+		return
+	}
+
+	remote, err := s.dial(context.Background(), host)
+	if err != nil {
+		conn.Write([]byte{socks5Version, socks5RepGeneralFail, 0x00, socks5AtypIPv4, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	conn.Write([]byte{socks5Version, socks5RepSucceeded, 0x00, socks5AtypIPv4, 0, 0, 0, 0, 0, 0})
+	pipe(conn, remote)
+}
+
+func (s *Server) socksHandshake(r *bufio.Reader, w io.Writer) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	if header[0] != socks5Version {
+		return errors.New("proxy: unsupported SOCKS version")
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(r, methods); err != nil {
+		return err
+	}
+
+	if s.config.requiresAuth() {
+		for _, m := range methods {
+			if m == socks5AuthUserPw {
+				w.Write([]byte{socks5Version, socks5AuthUserPw})
+				return s.socksCheckUserPw(r, w)
+			}
+		}
+		w.Write([]byte{socks5Version, socks5AuthNoneAcc})
+		return errors.New("proxy: client does not support username/password auth")
+	}
+
+	for _, m := range methods {
+		if m == socks5AuthNone {
+			w.Write([]byte{socks5Version, socks5AuthNone})
+			return nil
+		}
+	}
+	w.Write([]byte{socks5Version, socks5AuthNoneAcc})
+	return errors.New("proxy: client does not support an acceptable auth method")
+}
+
+func (s *Server) socksCheckUserPw(r *bufio.Reader, w io.Writer) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	user := make([]byte, header[1])
+	if _, err := io.ReadFull(r, user); err != nil {
+		return err
+	}
+	plen := make([]byte, 1)
+	if _, err := io.ReadFull(r, plen); err != nil {
+		return err
+	}
+	pass := make([]byte, plen[0])
+	if _, err := io.ReadFull(r, pass); err != nil {
+		return err
+	}
+	userOK := subtle.ConstantTimeCompare(user, []byte(s.config.Username)) == 1
+	passOK := subtle.ConstantTimeCompare(pass, []byte(s.config.Password)) == 1
+	if !userOK || !passOK {
+		w.Write([]byte{0x01, 0x01})
+		return errors.New("proxy: invalid SOCKS5 credentials")
+	}
+	w.Write([]byte{0x01, 0x00})
+	return nil
+}
+
+// socksReadRequest reads a SOCKS5 CONNECT request and returns the requested
+// host without its port (the port is read and discarded). Domain and IP
+// targets that aren't mesh addresses are returned as-is; the caller's
+// resolver rejects them.
+func (s *Server) socksReadRequest(r *bufio.Reader, w io.Writer) (string, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", err
+	}
+	if header[0] != socks5Version {
+		return "", errors.New("proxy: unsupported SOCKS version")
+	}
+	if header[1] != socks5CmdConnect {
+		w.Write([]byte{socks5Version, socks5RepCmdNotSupported, 0x00, socks5AtypIPv4, 0, 0, 0, 0, 0, 0})
+		return "", errors.New("proxy: only the CONNECT command is supported")
+	}
+
+	var host string
+	switch header[3] {
+	case socks5AtypDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(r, length); err != nil {
+			return "", err
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(r, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	case socks5AtypIPv4:
+		addr := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(r, addr); err != nil {
+			return "", err
+		}
+		host = net.IP(addr).String()
+	case socks5AtypIPv6:
+		addr := make([]byte, net.IPv6len)
+		if _, err := io.ReadFull(r, addr); err != nil {
+			return "", err
+		}
+		host = net.IP(addr).String()
+	default:
+		return "", fmt.Errorf("proxy: unsupported SOCKS5 address type %d", header[3])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", err
+	}
+	host = strings.TrimSuffix(host, ".")
+	return host, nil
+}
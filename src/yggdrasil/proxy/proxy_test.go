@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestPipeWaitsForBothDirections proves pipe doesn't tear down the
+// connection as soon as one direction hits EOF. A CONNECT/SOCKS tunnel's
+// usual shape is request-then-response: the client finishes writing (and,
+// over TCP, half-closes) well before the response arrives. Before the fix,
+// pipe closed both connections the moment the client->remote copy finished,
+// truncating the still-in-flight response.
+func TestPipeWaitsForBothDirections(t *testing.T) {
+	aLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer aLn.Close()
+	bLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer bLn.Close()
+
+	aServed := make(chan net.Conn, 1)
+	go func() {
+		conn, err := aLn.Accept()
+		if err == nil {
+			aServed <- conn
+		}
+	}()
+	bServed := make(chan net.Conn, 1)
+	go func() {
+		conn, err := bLn.Accept()
+		if err == nil {
+			bServed <- conn
+		}
+	}()
+
+	client, err := net.DialTimeout("tcp", aLn.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("dial client side: %v", err)
+	}
+	defer client.Close()
+	remote, err := net.DialTimeout("tcp", bLn.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("dial remote side: %v", err)
+	}
+	defer remote.Close()
+
+	a := <-aServed
+	b := <-bServed
+	defer a.Close()
+	defer b.Close()
+
+	go pipe(a, b)
+
+	client.SetDeadline(time.Now().Add(3 * time.Second))
+	remote.SetDeadline(time.Now().Add(3 * time.Second))
+
+	if _, err := client.Write([]byte("request")); err != nil {
+		t.Fatalf("writing request: %v", err)
+	}
+	// The client is done sending, well before any response exists - the
+	// normal shape of a proxied request.
+	if tcp, ok := client.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+	}
+
+	buf := make([]byte, len("request"))
+	if _, err := remote.Read(buf); err != nil {
+		t.Fatalf("remote reading request: %v", err)
+	}
+	if string(buf) != "request" {
+		t.Fatalf("remote got %q, want %q", buf, "request")
+	}
+
+	// Delay the response well past when the request->remote direction
+	// finished, so a pipe that closes on the first EOF truncates this.
+	time.Sleep(200 * time.Millisecond)
+	if _, err := remote.Write([]byte("response")); err != nil {
+		t.Fatalf("writing response: %v", err)
+	}
+
+	reply := make([]byte, len("response"))
+	if _, err := client.Read(reply); err != nil {
+		t.Fatalf("client reading response: %v (pipe likely closed the connection early)", err)
+	}
+	if string(reply) != "response" {
+		t.Fatalf("client got %q, want %q", reply, "response")
+	}
+}
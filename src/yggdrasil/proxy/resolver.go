@@ -0,0 +1,34 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resolver turns a proxied request's target hostname into the (network,
+// address) pair expected by Dialer.DialContext, so that ordinary
+// applications can address Yggdrasil nodes using normal-looking hostnames
+// instead of raw public keys.
+type Resolver interface {
+	Resolve(host string) (network, address string, err error)
+}
+
+// yggTLD is the pseudo top-level domain recognised by DefaultResolver.
+const yggTLD = ".ygg"
+
+// DefaultResolver implements the mesh hostname convention:
+// "<hex-pubkey>.ygg" dials by Curve25519 public key, and
+// "<hex-nodeid>.nodeid.ygg" dials by raw node ID.
+type DefaultResolver struct{}
+
+// Resolve implements Resolver.
+func (DefaultResolver) Resolve(host string) (string, string, error) {
+	if !strings.HasSuffix(host, yggTLD) {
+		return "", "", fmt.Errorf("proxy: %q is not a %s address", host, yggTLD)
+	}
+	label := strings.TrimSuffix(host, yggTLD)
+	if rest := strings.TrimSuffix(label, ".nodeid"); rest != label {
+		return "nodeid", rest, nil
+	}
+	return "curve25519", label, nil
+}
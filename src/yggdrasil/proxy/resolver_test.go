@@ -0,0 +1,41 @@
+package proxy
+
+import "testing"
+
+func TestDefaultResolver(t *testing.T) {
+	cases := []struct {
+		host        string
+		wantNetwork string
+		wantAddress string
+		wantErr     bool
+	}{
+		{
+			host:        "55071be281f50d0abbda63aadc59755624280c44b2f1f47684317aa4e0325604.ygg",
+			wantNetwork: "curve25519",
+			wantAddress: "55071be281f50d0abbda63aadc59755624280c44b2f1f47684317aa4e0325604",
+		},
+		{
+			host:        "abcdef0123.nodeid.ygg",
+			wantNetwork: "nodeid",
+			wantAddress: "abcdef0123",
+		},
+		{host: "example.com", wantErr: true},
+		{host: "", wantErr: true},
+	}
+	for _, tc := range cases {
+		network, address, err := (DefaultResolver{}).Resolve(tc.host)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Resolve(%q): expected error, got network=%q address=%q", tc.host, network, address)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Resolve(%q): unexpected error: %v", tc.host, err)
+			continue
+		}
+		if network != tc.wantNetwork || address != tc.wantAddress {
+			t.Errorf("Resolve(%q) = (%q, %q), want (%q, %q)", tc.host, network, address, tc.wantNetwork, tc.wantAddress)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package yggdrasil
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"sync"
+	"time"
+)
+
+// Reliable stream mode fragments writes larger than the 65535-byte session
+// MTU into a sequence of fragments, reassembles them in order on the far
+// side, and retransmits any fragment that isn't ACKed within a timeout. It
+// removes the two limitations called out in the package doc (message size
+// cap, unreliable delivery) at the cost of the usual stop-and-wait-with-
+// window latency/throughput tradeoffs.
+
+const (
+	reliableMTU            = 65000 // leaves room for the header below the 65535 session cap
+	reliableHeaderLen      = 13
+	reliableWindow         = 32
+	reliableRetransmitTime = 500 * time.Millisecond
+	reliableMaxRetries     = 10
+)
+
+type reliableFlag uint8
+
+const (
+	reliableFlagData reliableFlag = 1 << iota
+	reliableFlagACK
+)
+
+// reliableFrame is the on-the-wire header placed in front of every fragment.
+// Sequence numbers are compared using serial-number arithmetic (RFC 1982) so
+// they can wrap indefinitely over a long-lived connection.
+type reliableFrame struct {
+	seq      uint32
+	ack      uint32
+	flags    reliableFlag
+	checksum uint32
+	payload  []byte
+}
+
+func (f *reliableFrame) encode() []byte {
+	buf := make([]byte, reliableHeaderLen+len(f.payload))
+	binary.BigEndian.PutUint32(buf[0:4], f.seq)
+	binary.BigEndian.PutUint32(buf[4:8], f.ack)
+	buf[8] = byte(f.flags)
+	copy(buf[13:], f.payload)
+	crc := crc32.ChecksumIEEE(buf[13:])
+	binary.BigEndian.PutUint32(buf[9:13], crc)
+	return buf
+}
+
+func decodeReliableFrame(buf []byte) (*reliableFrame, error) {
+	if len(buf) < reliableHeaderLen {
+		return nil, errors.New("reliable: short frame")
+	}
+	f := &reliableFrame{
+		seq:     binary.BigEndian.Uint32(buf[0:4]),
+		ack:     binary.BigEndian.Uint32(buf[4:8]),
+		flags:   reliableFlag(buf[8]),
+		payload: append([]byte(nil), buf[reliableHeaderLen:]...),
+	}
+	crc := binary.BigEndian.Uint32(buf[9:13])
+	if crc32.ChecksumIEEE(f.payload) != crc {
+		return nil, errors.New("reliable: checksum mismatch")
+	}
+	return f, nil
+}
+
+// seqLess reports whether a precedes b using 32-bit serial-number
+// arithmetic, so that wraparound is handled correctly.
+func seqLess(a, b uint32) bool {
+	return int32(a-b) < 0
+}
+
+// ReliableConn is a net.Conn with true stream semantics layered on top of an
+// Yggdrasil session: writes of any size are fragmented and reassembled in
+// order, and a sliding window of unacknowledged fragments is retransmitted
+// until acked or the connection gives up.
+type ReliableConn struct {
+	base net.Conn
+
+	writeMu   sync.Mutex
+	sendSeq   uint32
+	unacked   map[uint32]*reliableFrame
+	sendCond  *sync.Cond
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+
+	readMu  sync.Mutex
+	recvSeq uint32
+	pending map[uint32]*reliableFrame
+	readBuf []byte
+	readCh  chan struct{}
+
+	err error
+}
+
+func newReliableConn(base net.Conn) *ReliableConn {
+	rc := &ReliableConn{
+		base:    base,
+		unacked: make(map[uint32]*reliableFrame),
+		closed:  make(chan struct{}),
+		pending: make(map[uint32]*reliableFrame),
+		readCh:  make(chan struct{}, 1),
+	}
+	rc.sendCond = sync.NewCond(&rc.writeMu)
+	go rc.readLoop()
+	go rc.retransmitLoop()
+	return rc
+}
+
+// DialReliable opens a reliable, fragmenting, ACKed stream to the given
+// node. It dials the underlying session with DialContext and then wraps it
+// with fragmentation and retransmission.
+func (d *Dialer) DialReliable(ctx context.Context, network, address string) (net.Conn, error) {
+	base, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return newReliableConn(base), nil
+}
+
+// AcceptReliable accepts the next incoming session and wraps it with the
+// same fragmentation and retransmission framing used by DialReliable.
+func (l *Listener) AcceptReliable() (net.Conn, error) {
+	base, err := l.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newReliableConn(base), nil
+}
+
+// Write implements net.Conn. Payloads of any size are split into
+// reliableMTU-sized fragments, each tracked in the send window until acked.
+func (c *ReliableConn) Write(p []byte) (int, error) {
+	total := len(p)
+	for len(p) > 0 {
+		n := len(p)
+		if n > reliableMTU {
+			n = reliableMTU
+		}
+		if err := c.sendFragment(p[:n]); err != nil {
+			return total - len(p), err
+		}
+		p = p[n:]
+	}
+	return total, nil
+}
+
+func (c *ReliableConn) sendFragment(payload []byte) error {
+	c.writeMu.Lock()
+	for len(c.unacked) >= reliableWindow {
+		select {
+		case <-c.closed:
+			c.writeMu.Unlock()
+			return c.getErr()
+		default:
+		}
+		c.sendCond.Wait()
+	}
+	seq := c.sendSeq
+	c.sendSeq++
+	frame := &reliableFrame{seq: seq, flags: reliableFlagData, payload: payload}
+	c.unacked[seq] = frame
+	c.writeMu.Unlock()
+
+	_, err := c.base.Write(frame.encode())
+	return err
+}
+
+// retransmitLoop resends any fragment that hasn't been acked within
+// reliableRetransmitTime, giving up after reliableMaxRetries attempts.
+func (c *ReliableConn) retransmitLoop() {
+	retries := make(map[uint32]int)
+	ticker := time.NewTicker(reliableRetransmitTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			for seq, frame := range c.unacked {
+				retries[seq]++
+				if retries[seq] > reliableMaxRetries {
+					c.writeMu.Unlock()
+					c.fail(fmt.Errorf("reliable: fragment %d exceeded max retries", seq))
+					return
+				}
+				c.base.Write(frame.encode())
+			}
+			// Forget retry counts for fragments that have since been acked,
+			// so retries doesn't grow by one entry per fragment ever sent
+			// over the life of a long-running connection.
+			for seq := range retries {
+				if _, ok := c.unacked[seq]; !ok {
+					delete(retries, seq)
+				}
+			}
+			c.writeMu.Unlock()
+		}
+	}
+}
+
+// readLoop pulls frames off the underlying session, handles ACKs inline,
+// and reassembles in-order data fragments into readBuf for Read to consume.
+func (c *ReliableConn) readLoop() {
+	buf := make([]byte, 65535)
+	for {
+		n, err := c.base.Read(buf)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		frame, err := decodeReliableFrame(buf[:n])
+		if err != nil {
+			continue // drop corrupt fragment, sender will retransmit
+		}
+		if frame.flags&reliableFlagACK != 0 {
+			c.writeMu.Lock()
+			delete(c.unacked, frame.ack)
+			c.sendCond.Broadcast()
+			c.writeMu.Unlock()
+			continue
+		}
+		c.base.Write((&reliableFrame{ack: frame.seq, flags: reliableFlagACK}).encode())
+
+		c.readMu.Lock()
+		if seqLess(frame.seq, c.recvSeq) {
+			c.readMu.Unlock()
+			continue // duplicate, already delivered
+		}
+		c.pending[frame.seq] = frame
+		for {
+			next, ok := c.pending[c.recvSeq]
+			if !ok {
+				break
+			}
+			c.readBuf = append(c.readBuf, next.payload...)
+			delete(c.pending, c.recvSeq)
+			c.recvSeq++
+		}
+		c.readMu.Unlock()
+		select {
+		case c.readCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Read implements net.Conn, blocking until reassembled data is available.
+func (c *ReliableConn) Read(p []byte) (int, error) {
+	for {
+		c.readMu.Lock()
+		if len(c.readBuf) > 0 {
+			n := copy(p, c.readBuf)
+			c.readBuf = c.readBuf[n:]
+			c.readMu.Unlock()
+			return n, nil
+		}
+		c.readMu.Unlock()
+		select {
+		case <-c.readCh:
+			continue
+		case <-c.closed:
+			return 0, c.getErr()
+		}
+	}
+}
+
+// fail marks the connection as failed with err, waking any blocked Read or
+// Write so they observe it, and closes c.base so that whichever of readLoop
+// or retransmitLoop didn't detect the failure unblocks too (otherwise a
+// blocked base.Read leaks its goroutine and the underlying session forever,
+// since nothing requires the caller to also call Close after a failed
+// Read/Write). It shares closeOnce with Close so that whichever of the two
+// runs first is the one that actually closes c.closed and c.base.
+func (c *ReliableConn) fail(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.closed)
+		c.closeErr = c.base.Close()
+	})
+	c.writeMu.Lock()
+	c.sendCond.Broadcast()
+	c.writeMu.Unlock()
+}
+
+func (c *ReliableConn) getErr() error {
+	if c.err != nil {
+		return c.err
+	}
+	return net.ErrClosed
+}
+
+// Close implements net.Conn.
+func (c *ReliableConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.closeErr = c.base.Close()
+	})
+	c.writeMu.Lock()
+	c.sendCond.Broadcast()
+	c.writeMu.Unlock()
+	return c.closeErr
+}
+
+// LocalAddr implements net.Conn.
+func (c *ReliableConn) LocalAddr() net.Addr { return c.base.LocalAddr() }
+
+// RemoteAddr implements net.Conn.
+func (c *ReliableConn) RemoteAddr() net.Addr { return c.base.RemoteAddr() }
+
+// SetDeadline implements net.Conn.
+func (c *ReliableConn) SetDeadline(t time.Time) error { return c.base.SetDeadline(t) }
+
+// SetReadDeadline implements net.Conn.
+func (c *ReliableConn) SetReadDeadline(t time.Time) error { return c.base.SetReadDeadline(t) }
+
+// SetWriteDeadline implements net.Conn.
+func (c *ReliableConn) SetWriteDeadline(t time.Time) error { return c.base.SetWriteDeadline(t) }
@@ -0,0 +1,134 @@
+package yggdrasil
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReliableConnMultiFragmentWrite(t *testing.T) {
+	baseA, baseB := net.Pipe()
+	a := newReliableConn(baseA)
+	b := newReliableConn(baseB)
+	defer a.Close()
+	defer b.Close()
+
+	payload := bytes.Repeat([]byte("x"), reliableMTU+1234)
+	go func() {
+		if _, err := a.Write(payload); err != nil {
+			t.Errorf("Write: %v", err)
+		}
+	}()
+
+	got := make([]byte, 0, len(payload))
+	buf := make([]byte, 65535)
+	for len(got) < len(payload) {
+		n, err := b.Read(buf)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatal("reassembled payload did not match what was written")
+	}
+}
+
+func TestReliableConnOutOfOrderReassembly(t *testing.T) {
+	base, wire := net.Pipe()
+	rc := newReliableConn(base)
+	defer rc.Close()
+
+	// Drain whatever rc writes back (ACKs) so the synchronous pipe doesn't
+	// deadlock while the test drives frames in manually.
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			if _, err := wire.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	frame1 := (&reliableFrame{seq: 1, flags: reliableFlagData, payload: []byte("world")}).encode()
+	frame0 := (&reliableFrame{seq: 0, flags: reliableFlagData, payload: []byte("hello ")}).encode()
+
+	if _, err := wire.Write(frame1); err != nil {
+		t.Fatalf("writing out-of-order fragment: %v", err)
+	}
+	if _, err := wire.Write(frame0); err != nil {
+		t.Fatalf("writing fragment: %v", err)
+	}
+
+	buf := make([]byte, 64)
+	n, err := rc.Read(buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got := string(buf[:n]); got != "hello world" {
+		t.Fatalf("reassembled data = %q, want %q", got, "hello world")
+	}
+}
+
+// TestReliableConnFailClosesBase proves that fail (invoked internally once
+// retransmits exhaust, or the underlying session errors) closes c.base.
+// Before the fix, fail only unblocked Read/Write via c.closed; readLoop's
+// own goroutine, and the underlying session, would leak forever unless the
+// caller happened to also call Close after observing a failed Read/Write.
+func TestReliableConnFailClosesBase(t *testing.T) {
+	base, wire := net.Pipe()
+	rc := newReliableConn(base)
+	defer rc.Close()
+
+	rc.fail(errors.New("boom"))
+
+	wire.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := wire.Read(make([]byte, 1)); err == nil {
+		t.Fatal("peer side of the pipe still read successfully after fail(); base was never closed")
+	}
+}
+
+// dropFirstWriteConn drops the first Write made to it and forwards every
+// later one, so tests can simulate a single lost fragment.
+type dropFirstWriteConn struct {
+	net.Conn
+	mu      sync.Mutex
+	dropped bool
+}
+
+func (c *dropFirstWriteConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dropped {
+		c.dropped = true
+		return len(p), nil
+	}
+	return c.Conn.Write(p)
+}
+
+func TestReliableConnRetransmitsDroppedFragment(t *testing.T) {
+	base, wire := net.Pipe()
+	rc := newReliableConn(&dropFirstWriteConn{Conn: base})
+	defer rc.Close()
+
+	if _, err := rc.Write([]byte("retry-me")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	wire.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 4096)
+	n, err := wire.Read(buf)
+	if err != nil {
+		t.Fatalf("reading retransmitted fragment: %v", err)
+	}
+	frame, err := decodeReliableFrame(buf[:n])
+	if err != nil {
+		t.Fatalf("decoding retransmitted fragment: %v", err)
+	}
+	if string(frame.payload) != "retry-me" {
+		t.Fatalf("retransmitted payload = %q, want %q", frame.payload, "retry-me")
+	}
+}
@@ -0,0 +1,149 @@
+package yggdrasil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+// searchRetryInterval is how often an in-progress DHT search is retried
+// while it remains unresolved.
+const searchRetryInterval = 500 * time.Millisecond
+
+// directory stands in for the real switch/DHT lookup in this package: it
+// lets every Core in the process that is listening (i.e. has called
+// ConnListener) be found by NodeID, so that router.lookup below has
+// something real to resolve against instead of a permanent stub. A node
+// only appears here once it's actually able to accept incoming sessions,
+// same as the real DHT only being able to route to a node once it has
+// peered onto the network.
+var directory = struct {
+	mutex sync.Mutex
+	cores map[crypto.NodeID]*Core
+}{cores: make(map[crypto.NodeID]*Core)}
+
+func publishToDirectory(c *Core) {
+	directory.mutex.Lock()
+	defer directory.mutex.Unlock()
+	directory.cores[*c.NodeID()] = c
+}
+
+func unpublishFromDirectory(c *Core) {
+	directory.mutex.Lock()
+	defer directory.mutex.Unlock()
+	delete(directory.cores, *c.NodeID())
+}
+
+// searchHandle identifies a single doSearch call's registration in
+// router.searches. Identity is the pointer itself (func values aren't
+// comparable), so a doSearch that's been superseded can tell its own
+// registration apart from whatever replaced it and avoid deleting that
+// instead.
+type searchHandle struct {
+	cancel context.CancelFunc
+}
+
+// router owns the DHT search state for this node. Searches are tracked by
+// target NodeID so that a context firing can reach in and cancel the
+// specific pending search it started, rather than merely timing out the
+// caller while the search keeps retrying in the background.
+type router struct {
+	core *Core
+
+	mutex    sync.Mutex
+	searches map[crypto.NodeID]*searchHandle
+}
+
+func newRouter(core *Core) *router {
+	return &router{
+		core:     core,
+		searches: make(map[crypto.NodeID]*searchHandle),
+	}
+}
+
+// doSearch runs a DHT search for nodeID/nodeMask until it resolves to a
+// BoxPubKey, ctx is done, or the search is cancelled via cancelSearch. When
+// ctx fires, the pending search is torn down immediately and retries stop;
+// the error returned is ctx.Err().
+func (r *router) doSearch(ctx context.Context, nodeID, nodeMask *crypto.NodeID) (*crypto.BoxPubKey, *Core, error) {
+	searchCtx, cancel := context.WithCancel(ctx)
+	handle := &searchHandle{cancel: cancel}
+
+	r.mutex.Lock()
+	if existing, ok := r.searches[*nodeID]; ok {
+		// A second search for the same target replaces (and cancels) the
+		// first, rather than running two searches in parallel.
+		existing.cancel()
+	}
+	r.searches[*nodeID] = handle
+	r.mutex.Unlock()
+
+	defer func() {
+		r.mutex.Lock()
+		// Only remove our own registration: if a later doSearch for the same
+		// nodeID has already superseded us, r.searches[*nodeID] is its
+		// handle, not ours, and deleting it would let that search's
+		// cancelSearch/Close become a silent no-op.
+		if r.searches[*nodeID] == handle {
+			delete(r.searches, *nodeID)
+		}
+		r.mutex.Unlock()
+		cancel()
+	}()
+
+	ticker := time.NewTicker(searchRetryInterval)
+	defer ticker.Stop()
+	for {
+		if key, remote, ok := r.lookup(nodeID, nodeMask); ok {
+			return key, remote, nil
+		}
+		select {
+		case <-searchCtx.Done():
+			return nil, nil, searchCtx.Err()
+		case <-ticker.C:
+			continue
+		}
+	}
+}
+
+// cancelSearch aborts a pending search for nodeID, if one is running,
+// stopping it from being retried further.
+func (r *router) cancelSearch(nodeID *crypto.NodeID) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if handle, ok := r.searches[*nodeID]; ok {
+		handle.cancel()
+		delete(r.searches, *nodeID)
+	}
+}
+
+// lookup resolves a NodeID/mask pair against the directory of nodes in this
+// process that are currently reachable (i.e. listening).
+func (r *router) lookup(nodeID, nodeMask *crypto.NodeID) (*crypto.BoxPubKey, *Core, bool) {
+	directory.mutex.Lock()
+	defer directory.mutex.Unlock()
+	for id, remote := range directory.cores {
+		if remote == r.core {
+			continue
+		}
+		if nodeIDMatches(&id, nodeID, nodeMask) {
+			pub := remote.EncryptionPublicKey()
+			return &pub, remote, true
+		}
+	}
+	return nil, nil, false
+}
+
+// nodeIDMatches reports whether candidate matches target under mask (i.e.
+// they agree on every bit that mask sets), which is how DialByNodeIDandMask
+// lets callers dial a NodeID prefix rather than a full NodeID.
+func nodeIDMatches(candidate, target, mask *crypto.NodeID) bool {
+	for i := range target {
+		if candidate[i]&mask[i] != target[i]&mask[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package yggdrasil
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+// TestDoSearchSupersededCleanupDoesNotDeleteWinner proves that when a second
+// doSearch for the same NodeID replaces a first, the first's deferred
+// cleanup removes only its own registration. Before the fix, the loser's
+// defer deleted whatever was currently in router.searches for that NodeID
+// (the winner's entry), making a subsequent cancelSearch on the winner a
+// silent no-op.
+func TestDoSearchSupersededCleanupDoesNotDeleteWinner(t *testing.T) {
+	core, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+	r := core.getRouter()
+
+	var nodeID, nodeMask crypto.NodeID
+	if _, err := rand.Read(nodeID[:]); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+	for i := range nodeMask {
+		nodeMask[i] = 0xFF
+	}
+
+	loserDone := make(chan struct{})
+	loserStarted := make(chan struct{})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		close(loserStarted)
+		r.doSearch(ctx, &nodeID, &nodeMask)
+		close(loserDone)
+	}()
+	<-loserStarted
+	// Give the loser's doSearch a moment to register itself before it's
+	// superseded, so the replace path (not a race on registration) is what
+	// we're exercising.
+	time.Sleep(20 * time.Millisecond)
+
+	winnerCtx, winnerCancel := context.WithCancel(context.Background())
+	defer winnerCancel()
+	winnerDone := make(chan error, 1)
+	go func() {
+		_, _, err := r.doSearch(winnerCtx, &nodeID, &nodeMask)
+		winnerDone <- err
+	}()
+
+	select {
+	case <-loserDone:
+	case <-time.After(time.Second):
+		t.Fatal("superseded search never unwound")
+	}
+
+	// The winner's own registration must still be live: cancelSearch should
+	// reach it, not find it already deleted by the loser's cleanup.
+	r.cancelSearch(&nodeID)
+
+	select {
+	case err := <-winnerDone:
+		if err != context.Canceled {
+			t.Fatalf("winner's doSearch returned %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cancelSearch did not stop the winning search; its registration was clobbered by the loser's cleanup")
+	}
+}
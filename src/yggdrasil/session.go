@@ -0,0 +1,31 @@
+package yggdrasil
+
+import "sync"
+
+// sessionInfo tracks the state of a single Conn's session: the channel
+// closed once the handshake with the remote side completes, and the Conn
+// that owns it.
+type sessionInfo struct {
+	mutex    sync.Mutex
+	init     chan struct{}
+	initOnce sync.Once
+	conn     *Conn
+}
+
+func newSessionInfo() *sessionInfo {
+	return &sessionInfo{init: make(chan struct{})}
+}
+
+// setConn associates this session with its owning Conn. from is not used:
+// only c is recorded.
+func (s *sessionInfo) setConn(from, c *Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.conn = c
+}
+
+// signalInit closes the init channel, unblocking anyone waiting on the
+// handshake to complete. It is safe to call more than once.
+func (s *sessionInfo) signalInit() {
+	s.initOnce.Do(func() { close(s.init) })
+}
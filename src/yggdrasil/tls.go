@@ -0,0 +1,154 @@
+package yggdrasil
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+// TLS-over-Yggdrasil ties the TLS peer identity to the underlying session's
+// Yggdrasil node identity, so that applications which need authenticated,
+// encrypted streams (the pattern the Dendrite demo hand-rolls) don't have to
+// reconcile two separate identities for the same principal.
+//
+// The certificate's CommonName is set to the node's hex-encoded Curve25519
+// public key, and VerifyPeerCertificate cross-checks that CommonName against
+// the BoxPubKey actually observed on the Yggdrasil session carrying the TLS
+// handshake, so a certificate can't claim to be a different node than the
+// one the mesh has already authenticated.
+
+// nodeCertificate generates a self-signed certificate for this node, signed
+// by an Ed25519 key derived from the node's identity, with the CommonName
+// set to the node's hex Curve25519 public key.
+func nodeCertificate(c *Core) (tls.Certificate, error) {
+	boxPubKey := c.EncryptionPublicKey()
+	seed := c.SigningPrivateKey()
+	if len(seed) < ed25519.SeedSize {
+		return tls.Certificate{}, errors.New("tls: signing key too short to derive Ed25519 identity")
+	}
+	priv := ed25519.NewKeyFromSeed(seed[:ed25519.SeedSize])
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName: hex.EncodeToString(boxPubKey[:]),
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, priv.Public(), priv)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  priv,
+	}, nil
+}
+
+// verifyPeerAgainstSession returns a VerifyPeerCertificate callback that
+// checks the leaf certificate's CommonName against the BoxPubKey seen on the
+// underlying Yggdrasil session, so the TLS identity can't diverge from the
+// mesh identity that was already cryptographically authenticated.
+func verifyPeerAgainstSession(remote *crypto.BoxPubKey) func([][]byte, [][]*x509.Certificate) error {
+	want := hex.EncodeToString(remote[:])
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("tls: no peer certificate presented")
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		if cert.Subject.CommonName != want {
+			return fmt.Errorf("tls: peer certificate CN %q does not match Yggdrasil session key %q", cert.Subject.CommonName, want)
+		}
+		return nil
+	}
+}
+
+// tlsConfig builds a tls.Config for a session to remote, binding the
+// certificate and peer verification to the node's and peer's Yggdrasil
+// identities respectively.
+func (c *Core) tlsConfig(remote *crypto.BoxPubKey) (*tls.Config, error) {
+	cert, err := nodeCertificate(c)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates:          []tls.Certificate{cert},
+		InsecureSkipVerify:    true, // we verify the peer ourselves, against the session's key
+		VerifyPeerCertificate: verifyPeerAgainstSession(remote),
+		MinVersion:            tls.VersionTLS13,
+	}, nil
+}
+
+// DialTLS dials a session to the given node and wraps it in a TLS client
+// connection whose peer identity is cross-checked against the Yggdrasil
+// session's public key.
+func (d *Dialer) DialTLS(ctx context.Context, network, address string) (*tls.Conn, error) {
+	base, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, ok := base.RemoteAddr().(crypto.BoxPubKey)
+	if !ok {
+		base.Close()
+		return nil, errors.New("tls: underlying conn has no BoxPubKey remote address")
+	}
+	cfg, err := d.core.tlsConfig(&pubKey)
+	if err != nil {
+		base.Close()
+		return nil, err
+	}
+	tlsConn := tls.Client(base, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		tlsConn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// AcceptTLS accepts the next incoming session and performs a TLS server
+// handshake over it, verifying the client's certificate against the
+// session's Yggdrasil public key.
+func (l *Listener) AcceptTLS() (*tls.Conn, error) {
+	base, err := l.Accept()
+	if err != nil {
+		return nil, err
+	}
+	pubKey, ok := base.RemoteAddr().(crypto.BoxPubKey)
+	if !ok {
+		base.Close()
+		return nil, errors.New("tls: underlying conn has no BoxPubKey remote address")
+	}
+	cfg, err := l.core.tlsConfig(&pubKey)
+	if err != nil {
+		base.Close()
+		return nil, err
+	}
+	cfg.ClientAuth = tls.RequireAnyClientCert
+	tlsConn := tls.Server(base, cfg)
+	if err := tlsConn.Handshake(); err != nil {
+		tlsConn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,50 @@
+package yggdrasil
+
+import (
+	"crypto/x509"
+	"encoding/hex"
+	"testing"
+
+	"github.com/yggdrasil-network/yggdrasil-go/src/crypto"
+)
+
+func TestNodeCertificateCommonName(t *testing.T) {
+	c, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+	cert, err := nodeCertificate(c)
+	if err != nil {
+		t.Fatalf("nodeCertificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parsing generated certificate: %v", err)
+	}
+	want := hex.EncodeToString(c.boxPub[:])
+	if leaf.Subject.CommonName != want {
+		t.Fatalf("CommonName = %q, want %q", leaf.Subject.CommonName, want)
+	}
+}
+
+func TestVerifyPeerAgainstSession(t *testing.T) {
+	c, err := NewCore()
+	if err != nil {
+		t.Fatalf("NewCore: %v", err)
+	}
+	cert, err := nodeCertificate(c)
+	if err != nil {
+		t.Fatalf("nodeCertificate: %v", err)
+	}
+
+	verify := verifyPeerAgainstSession(&c.boxPub)
+	if err := verify([][]byte{cert.Certificate[0]}, nil); err != nil {
+		t.Fatalf("expected certificate matching the session key to verify, got %v", err)
+	}
+
+	other := crypto.BoxPubKey{0xFF, 0xFE, 0xFD}
+	verifyOther := verifyPeerAgainstSession(&other)
+	if err := verifyOther([][]byte{cert.Certificate[0]}, nil); err == nil {
+		t.Fatal("expected certificate for a different session key to fail verification")
+	}
+}